@@ -24,6 +24,9 @@ import (
 
 	"git.fd.io/govpp.git/api"
 	"git.fd.io/govpp.git/core/bin_api/interfaces"
+
+	vppbridge "github.com/Billy99/vpp-agent/cnivpp/api/bridge"
+	vppxconnect "github.com/Billy99/vpp-agent/cnivpp/api/xconnect"
 )
 
 
@@ -33,7 +36,7 @@ func SetState(ch *api.Channel, swIfIndex uint32, isUp uint8) error {
 	req := &interfaces.SwInterfaceSetFlags{
 		SwIfIndex: swIfIndex,
 		// 1 = up, 0 = down
-		AdminUpDown: isUp, 
+		AdminUpDown: isUp,
 	}
 
 	reply := &interfaces.SwInterfaceSetFlagsReply{}
@@ -47,3 +50,108 @@ func SetState(ch *api.Channel, swIfIndex uint32, isUp uint8) error {
 
 	return nil
 }
+
+
+// Attempt to set an interface state over an existing govpp Stream - see the
+// "Stream API" section of cnivpp/api/bridge/bridge.go for why a Stream
+// instead of Channel.SendRequest. isUp (1 = up, 0 = down)
+func SetStateWithStream(s api.Stream, swIfIndex uint32, isUp uint8) error {
+	req := &interfaces.SwInterfaceSetFlags{
+		SwIfIndex: swIfIndex,
+		// 1 = up, 0 = down
+		AdminUpDown: isUp,
+	}
+
+	if err := s.SendMsg(req); err != nil {
+		fmt.Println("Error:", err)
+		return err
+	}
+
+	msg, err := s.RecvMsg()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return err
+	}
+
+	if _, ok := msg.(*interfaces.SwInterfaceSetFlagsReply); !ok {
+		return fmt.Errorf("unexpected reply type %T to SwInterfaceSetFlags", msg)
+	}
+
+	return nil
+}
+
+
+//
+// L2 mode
+//
+
+// L2Mode describes how an interface is currently attached to the L2 data
+// plane: cross-connected to a peer, a member of a Bridge Domain, or plain
+// L3 (not attached to either).
+type L2Mode interface {
+	isL2Mode()
+}
+
+// L2ModeNone means the interface is not attached to a Bridge Domain or
+// cross-connect.
+type L2ModeNone struct{}
+
+func (L2ModeNone) isL2Mode() {}
+
+// L2ModeBridge means the interface is a member of the given Bridge Domain.
+type L2ModeBridge struct {
+	BdID uint32
+}
+
+func (L2ModeBridge) isL2Mode() {}
+
+// L2ModeXConnect means the interface is bidirectionally cross-connected to
+// PeerSwIfIndex (an xconnect is created in both directions).
+type L2ModeXConnect struct {
+	PeerSwIfIndex uint32
+}
+
+func (L2ModeXConnect) isL2Mode() {}
+
+// AttachL2 moves an interface from its current L2 mode (from) to a new one
+// (to), tearing down the prior attachment before wiring up the new one. VPP
+// does not allow an interface to be an xconnect endpoint and a bridge
+// member at the same time, so the handoff always goes through a detach
+// step; common forwarders prefer xconnect for point-to-point traffic and
+// flip to a bridge domain once a second endpoint joins.
+func AttachL2(ch *api.Channel, swIfIndex uint32, from L2Mode, to L2Mode) error {
+
+	if err := detachL2(ch, swIfIndex, from); err != nil {
+		return err
+	}
+
+	return attachL2(ch, swIfIndex, to)
+}
+
+func detachL2(ch *api.Channel, swIfIndex uint32, mode L2Mode) error {
+	switch m := mode.(type) {
+	case L2ModeBridge:
+		return vppbridge.RemoveBridgeInterface(ch, m.BdID, swIfIndex)
+	case L2ModeXConnect:
+		if err := vppxconnect.DeleteXConnect(ch, swIfIndex, m.PeerSwIfIndex); err != nil {
+			return err
+		}
+		return vppxconnect.DeleteXConnect(ch, m.PeerSwIfIndex, swIfIndex)
+	default:
+		return nil
+	}
+}
+
+func attachL2(ch *api.Channel, swIfIndex uint32, mode L2Mode) error {
+	switch m := mode.(type) {
+	case L2ModeBridge:
+		return vppbridge.AddBridgeInterface(ch, m.BdID, swIfIndex)
+	case L2ModeXConnect:
+		if err := vppxconnect.CreateXConnect(ch, swIfIndex, m.PeerSwIfIndex); err != nil {
+			return err
+		}
+		return vppxconnect.CreateXConnect(ch, m.PeerSwIfIndex, swIfIndex)
+	default:
+		return nil
+	}
+}
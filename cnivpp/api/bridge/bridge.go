@@ -21,17 +21,71 @@ package vppbridge
 
 import (
 	"fmt"
+	"net"
 
 	"git.fd.io/govpp.git/api"
+	"git.fd.io/govpp.git/core/bin_api/interfaces"
 	"git.fd.io/govpp.git/core/bin_api/l2"
+	"git.fd.io/govpp.git/core/bin_api/vpe"
 )
 
+// bdIDWildcard is the value VPP treats as "all Bridge Domains" when used as
+// the BdID of a BridgeDomainDump request.
+const bdIDWildcard = ^uint32(0)
+
 
 //
 // Constants
 //
 const debugBridge = false
 
+// BdIDAuto requests that VPP allocate the Bridge Domain id rather than the
+// caller picking one. Pass this value as the BdID of a BridgeOptions and
+// read back the allocated id from CreateBridgeWithOptions().
+const BdIDAuto = ^uint32(0)
+
+
+//
+// Types
+//
+
+// BridgeOptions captures the tunable Bridge Domain attributes exposed by
+// bridge_domain_add_del_v2. Flood, UuFlood, Forward and Learn are the
+// familiar BD switches; ArpUfwd additionally forwards unknown unicast ARP
+// packets (v3.2.0 of l2.api) instead of flooding them like plain UuFlood.
+type BridgeOptions struct {
+	Flood   uint8
+	UuFlood uint8
+	Forward uint8
+	Learn   uint8
+	ArpTerm uint8
+	ArpUfwd uint8
+	MacAge  uint8
+	BdTag   string
+}
+
+// BridgeMember is one interface attached to a Bridge Domain.
+type BridgeMember struct {
+	SwIfIndex uint32
+	Shg       uint8
+}
+
+// BridgeInfo is the typed equivalent of an l2.BridgeDomainDetails reply,
+// returned by DumpAllBridges() instead of being printed to Stdout.
+type BridgeInfo struct {
+	BdID         uint32
+	Flood        uint8
+	UuFlood      uint8
+	Forward      uint8
+	Learn        uint8
+	ArpTerm      uint8
+	ArpUfwd      uint8
+	MacAge       uint8
+	BdTag        string
+	BviSwIfIndex uint32
+	Members      []BridgeMember
+}
+
 
 //
 // API Functions
@@ -42,12 +96,24 @@ const debugBridge = false
 // of VPP which the library is connected to.
 func BridgeCompatibilityCheck(ch *api.Channel) error {
 	err := ch.CheckMessageCompatibility(
-		&l2.BridgeDomainAddDel{},
-		&l2.BridgeDomainAddDelReply{},
+		&l2.BridgeDomainAddDelV2{},
+		&l2.BridgeDomainAddDelV2Reply{},
 		&l2.BridgeDomainDump{},
 		&l2.BridgeDomainDetails{},
 		&l2.SwInterfaceSetL2Bridge{},
 		&l2.SwInterfaceSetL2BridgeReply{},
+		&l2.BridgeDomainSetLearnLimit{},
+		&l2.BridgeDomainSetLearnLimitReply{},
+		&l2.BridgeDomainSetMacAge{},
+		&l2.BridgeDomainSetMacAgeReply{},
+		&l2.BviCreate{},
+		&l2.BviCreateReply{},
+		&l2.BviDelete{},
+		&l2.BviDeleteReply{},
+		&l2.BdIPMacAddDel{},
+		&l2.BdIPMacAddDelReply{},
+		&l2.BdIPMacDump{},
+		&l2.BdIPMacDetails{},
 	)
 	if err != nil {
 		if debugBridge {
@@ -59,31 +125,68 @@ func BridgeCompatibilityCheck(ch *api.Channel) error {
 }
 
 
-// Attempt to create a Bridge Domain.
+// Attempt to create a Bridge Domain, using the historical defaults
+// (Flood=UuFlood=Forward=Learn=1, ArpTerm=0, MacAge=0, no BdTag).
 func CreateBridge(ch *api.Channel, bridgeDomain uint32) (error) {
+	_, err := CreateBridgeWithOptions(ch, bridgeDomain, BridgeOptions{
+		Flood:   1,
+		UuFlood: 1,
+		Forward: 1,
+		Learn:   1,
+	})
 
-	exists,_ := findBridge(ch, bridgeDomain)
-	if exists {
-		if debugBridge {
-			fmt.Printf("Bridge Domain %d already exist, exit\n", bridgeDomain)
+	return err
+}
+
+
+// Attempt to create a Bridge Domain with the given options, via
+// bridge_domain_add_del_v2. Pass BdIDAuto as bridgeDomain to have VPP
+// allocate the id; the id actually used is always returned.
+func CreateBridgeWithOptions(ch *api.Channel, bridgeDomain uint32, opts BridgeOptions) (uint32, error) {
+
+	if bridgeDomain != BdIDAuto {
+		exists, _ := findBridge(ch, bridgeDomain)
+		if exists {
+			if debugBridge {
+				fmt.Printf("Bridge Domain %d already exist, exit\n", bridgeDomain)
+			}
+			return bridgeDomain, nil
 		}
-		return nil
 	}
 
+	return sendBridgeDomainAddDelV2(ch, bridgeDomain, opts)
+}
+
+
+// Retune the options of an existing Bridge Domain in place, bypassing the
+// exists-guard CreateBridgeWithOptions applies for its create-if-missing
+// behavior. bridge_domain_add_del_v2 updates an existing Bridge Domain's
+// attributes when IsAdd=1 is sent for an id that already exists, so no
+// delete/re-add cycle is needed.
+func UpdateBridgeOptions(ch *api.Channel, bridgeDomain uint32, opts BridgeOptions) (uint32, error) {
+	return sendBridgeDomainAddDelV2(ch, bridgeDomain, opts)
+}
+
+
+// sendBridgeDomainAddDelV2 unconditionally sends a bridge_domain_add_del_v2
+// create/update request, with no exists-guard of its own.
+func sendBridgeDomainAddDelV2(ch *api.Channel, bridgeDomain uint32, opts BridgeOptions) (uint32, error) {
+
 	// Populate the Request Structure
-	req := &l2.BridgeDomainAddDel{
+	req := &l2.BridgeDomainAddDelV2{
 		BdID: bridgeDomain,
-		Flood: 1,
-		UuFlood: 1,
-		Forward: 1,
-		Learn: 1,
-		ArpTerm: 0,
-		MacAge: 0,
-		//BdTag   []byte `struc:"[64]byte"`
+		Flood: opts.Flood,
+		UuFlood: opts.UuFlood,
+		Forward: opts.Forward,
+		Learn: opts.Learn,
+		ArpTerm: opts.ArpTerm,
+		ArpUfwd: opts.ArpUfwd,
+		MacAge: opts.MacAge,
+		BdTag: []byte(opts.BdTag),
 		IsAdd: 1,
 	}
 
-	reply := &l2.BridgeDomainAddDelReply{}
+	reply := &l2.BridgeDomainAddDelV2Reply{}
 
 	err := ch.SendRequest(req).ReceiveReply(reply)
 
@@ -91,10 +194,58 @@ func CreateBridge(ch *api.Channel, bridgeDomain uint32) (error) {
 		if debugBridge {
 			fmt.Println("Error creating bridge domain:", err)
 		}
+		return bridgeDomain, err
+	}
+
+	return reply.BdID, nil
+}
+
+
+// Retune the learn limit (max number of MAC addresses learned) of an
+// existing Bridge Domain without a delete/re-add cycle.
+func SetBridgeLearnLimit(ch *api.Channel, bridgeDomain uint32, learnLimit uint32) error {
+
+	req := &l2.BridgeDomainSetLearnLimit{
+		BdID: bridgeDomain,
+		LearnLimit: learnLimit,
+	}
+
+	reply := &l2.BridgeDomainSetLearnLimitReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		if debugBridge {
+			fmt.Println("Error setting learn limit on Bridge Domain:", err)
+		}
 		return err
 	}
 
-	return err
+	return nil
+}
+
+
+// Retune the MAC age-out time (in minutes, 0 = disabled) of an existing
+// Bridge Domain without a delete/re-add cycle.
+func SetBridgeMacAge(ch *api.Channel, bridgeDomain uint32, macAge uint8) error {
+
+	req := &l2.BridgeDomainSetMacAge{
+		BdID: bridgeDomain,
+		MacAge: macAge,
+	}
+
+	reply := &l2.BridgeDomainSetMacAgeReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		if debugBridge {
+			fmt.Println("Error setting MAC age on Bridge Domain:", err)
+		}
+		return err
+	}
+
+	return nil
 }
 
 
@@ -198,8 +349,8 @@ func RemoveBridgeInterface(ch *api.Channel, bridgeDomain uint32, swIfId uint32)
 }
 
 
-// Dump the input Bridge data to Stdout. There is not VPP API to dump
-// all the Bridges. 
+// Dump the input Bridge data to Stdout. To dump every configured Bridge
+// Domain instead of a single one, use DumpAllBridges.
 func DumpBridge(ch *api.Channel, bridgeDomain uint32) {
 
         // Populate the Message Structure
@@ -231,12 +382,321 @@ func DumpBridge(ch *api.Channel, bridgeDomain uint32) {
 					reply.SwIfDetails[i].Shg)
 			}
 		}
+
+		entries, err := dumpArpTermEntries(ch, bridgeDomain)
+		if err != nil {
+			if debugBridge {
+				fmt.Println("Error dumping ARP termination entries:", err)
+			}
+		}
+		for _, entry := range entries {
+			fmt.Printf("      ArpTerm IP=%s Mac=%s\n", entry.IP, entry.Mac)
+		}
 	} else {
 		fmt.Printf("Bridge Domain %d does NOT Exist.\n", bridgeDomain)
 	}
 }
 
 
+// Attempt to create a Bridge Virtual Interface for L3 routing into a
+// Bridge Domain, using l2.BviCreate. If the connected VPP predates that
+// message, fall back to interfaces.LoopbackCreate and attach it to the
+// Bridge Domain as a BVI via SetBridgeBVI.
+func CreateBVI(ch *api.Channel, bridgeDomain uint32, mac net.HardwareAddr) (uint32, error) {
+
+	swIfIndex, err := bviCreate(ch, mac)
+	if err != nil {
+		if debugBridge {
+			fmt.Println("bvi_create unavailable, falling back to loopback_create:", err)
+		}
+
+		swIfIndex, err = loopbackCreate(ch, mac)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := SetBridgeBVI(ch, bridgeDomain, swIfIndex); err != nil {
+		return 0, err
+	}
+
+	return swIfIndex, nil
+}
+
+
+// Attempt to delete a Bridge Virtual Interface previously created by
+// CreateBVI. Since CreateBVI may have fallen back to a plain loopback, try
+// the native l2.BviDelete first and fall back to interfaces.DeleteLoopback,
+// mirroring CreateBVI's own fallback.
+func DeleteBVI(ch *api.Channel, swIfIndex uint32) error {
+
+	err := bviDelete(ch, swIfIndex)
+	if err != nil {
+		if debugBridge {
+			fmt.Println("bvi_delete unavailable, falling back to delete_loopback:", err)
+		}
+
+		return loopbackDelete(ch, swIfIndex)
+	}
+
+	return nil
+}
+
+
+// Attach swIfIndex to a Bridge Domain as its Bridge Virtual Interface. This
+// patches the same SwInterfaceSetL2Bridge call path as AddBridgeInterface,
+// but with Bvi=1.
+func SetBridgeBVI(ch *api.Channel, bridgeDomain uint32, swIfIndex uint32) error {
+
+	if err := CreateBridge(ch, bridgeDomain); err != nil {
+		return err
+	}
+
+	req := &l2.SwInterfaceSetL2Bridge{
+		BdID: bridgeDomain,
+		RxSwIfIndex: swIfIndex,
+		Shg: 0,
+		Bvi: 1,
+		Enable: 1,
+	}
+
+	reply := &l2.SwInterfaceSetL2BridgeReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		if debugBridge {
+			fmt.Println("Error setting BVI on bridge domain:", err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+
+// ArpTermEntry is one static ARP-termination entry of a Bridge Domain.
+type ArpTermEntry struct {
+	IP  net.IP
+	Mac net.HardwareAddr
+}
+
+// Add a static ARP-termination entry to a Bridge Domain, for EVPN-style
+// silent hosts. ArpTerm is flipped on automatically on the Bridge Domain if
+// this is its first entry.
+func AddArpTermEntry(ch *api.Channel, bridgeDomain uint32, ip net.IP, mac net.HardwareAddr) error {
+
+	if err := enableArpTerm(ch, bridgeDomain); err != nil {
+		return err
+	}
+
+	return bdIPMacAddDel(ch, bridgeDomain, ip, mac, 1)
+}
+
+
+// Delete a static ARP-termination entry from a Bridge Domain.
+func DeleteArpTermEntry(ch *api.Channel, bridgeDomain uint32, ip net.IP, mac net.HardwareAddr) error {
+	return bdIPMacAddDel(ch, bridgeDomain, ip, mac, 0)
+}
+
+
+// Dump every configured Bridge Domain, using VPP's BdIDAuto-style wildcard
+// (BdID = ~0) rather than the per-BD SendMultiRequest tolerance findBridge()
+// needs. Returns a typed slice instead of printing to Stdout.
+func DumpAllBridges(ch *api.Channel) ([]BridgeInfo, error) {
+
+	req := &l2.BridgeDomainDump{
+		BdID: bdIDWildcard,
+	}
+	reqCtx := ch.SendMultiRequest(req)
+
+	var list []BridgeInfo
+
+	for {
+		reply := &l2.BridgeDomainDetails{}
+		stop, err := reqCtx.ReceiveReply(reply)
+		if stop {
+			break // break out of the loop
+		} else if err != nil {
+			if debugBridge {
+				fmt.Println("Error dumping Bridge Domains:", err)
+			}
+			return list, err
+		}
+
+		list = append(list, bridgeInfoFromDetails(reply))
+	}
+
+	return list, nil
+}
+
+
+//
+// Stream API
+//
+// The functions below mirror their Channel-based counterparts above, but
+// operate on a govpp core.Stream (api.Connection.NewStream(ctx)) so a batch
+// reconciler can pipeline many operations over one ordered stream instead
+// of paying the per-call goroutine/channel allocation of Channel.SendRequest.
+//
+
+// Attempt to create a Bridge Domain over an existing Stream. See
+// CreateBridgeWithOptions for the Channel-based equivalent. Unlike that
+// function there is no exists-guard: for a Bridge Domain that already
+// exists, its attributes are updated in place, as UpdateBridgeOptions does.
+func CreateBridgeWithStream(s api.Stream, bridgeDomain uint32, opts BridgeOptions) (uint32, error) {
+
+	req := &l2.BridgeDomainAddDelV2{
+		BdID: bridgeDomain,
+		Flood: opts.Flood,
+		UuFlood: opts.UuFlood,
+		Forward: opts.Forward,
+		Learn: opts.Learn,
+		ArpTerm: opts.ArpTerm,
+		ArpUfwd: opts.ArpUfwd,
+		MacAge: opts.MacAge,
+		BdTag: []byte(opts.BdTag),
+		IsAdd: 1,
+	}
+
+	if err := s.SendMsg(req); err != nil {
+		if debugBridge {
+			fmt.Println("Error creating bridge domain over stream:", err)
+		}
+		return bridgeDomain, err
+	}
+
+	msg, err := s.RecvMsg()
+	if err != nil {
+		if debugBridge {
+			fmt.Println("Error creating bridge domain over stream:", err)
+		}
+		return bridgeDomain, err
+	}
+
+	reply, ok := msg.(*l2.BridgeDomainAddDelV2Reply)
+	if !ok {
+		return bridgeDomain, fmt.Errorf("unexpected reply type %T to BridgeDomainAddDelV2", msg)
+	}
+
+	return reply.BdID, nil
+}
+
+
+// Attempt to add an interface to a Bridge Domain over an existing Stream.
+// Unlike AddBridgeInterface, the Bridge Domain is assumed to already exist;
+// callers batching over a Stream are expected to have created it with
+// CreateBridgeWithStream first.
+func AddBridgeInterfaceWithStream(s api.Stream, bridgeDomain uint32, swIfId uint32) error {
+
+	req := &l2.SwInterfaceSetL2Bridge{
+		BdID: bridgeDomain,
+		RxSwIfIndex: swIfId,
+		Shg: 0,
+		Bvi: 0,
+		Enable: 1,
+	}
+
+	if err := s.SendMsg(req); err != nil {
+		if debugBridge {
+			fmt.Println("Error adding interface to bridge domain over stream:", err)
+		}
+		return err
+	}
+
+	msg, err := s.RecvMsg()
+	if err != nil {
+		if debugBridge {
+			fmt.Println("Error adding interface to bridge domain over stream:", err)
+		}
+		return err
+	}
+
+	if _, ok := msg.(*l2.SwInterfaceSetL2BridgeReply); !ok {
+		return fmt.Errorf("unexpected reply type %T to SwInterfaceSetL2Bridge", msg)
+	}
+
+	return nil
+}
+
+
+// Dump every configured Bridge Domain over an existing Stream. See
+// DumpAllBridges for the Channel-based equivalent. Unlike a plain request/
+// reply pair, a dump has no fixed number of replies, so - exactly like
+// Channel.SendMultiRequest does internally - a vpe.ControlPing is sent
+// right after the dump request and its reply is used as the end-of-stream
+// sentinel; without it there is nothing to stop RecvMsg() from blocking
+// forever once the last BridgeDomainDetails has been delivered.
+func DumpAllBridgesWithStream(s api.Stream) ([]BridgeInfo, error) {
+
+	req := &l2.BridgeDomainDump{
+		BdID: bdIDWildcard,
+	}
+
+	if err := s.SendMsg(req); err != nil {
+		if debugBridge {
+			fmt.Println("Error dumping Bridge Domains over stream:", err)
+		}
+		return nil, err
+	}
+
+	if err := s.SendMsg(&vpe.ControlPing{}); err != nil {
+		if debugBridge {
+			fmt.Println("Error dumping Bridge Domains over stream:", err)
+		}
+		return nil, err
+	}
+
+	var list []BridgeInfo
+
+	for {
+		msg, err := s.RecvMsg()
+		if err != nil {
+			if debugBridge {
+				fmt.Println("Error dumping Bridge Domains over stream:", err)
+			}
+			return list, err
+		}
+
+		switch reply := msg.(type) {
+		case *l2.BridgeDomainDetails:
+			list = append(list, bridgeInfoFromDetails(reply))
+		case *vpe.ControlPingReply:
+			return list, nil
+		default:
+			return list, fmt.Errorf("unexpected reply type %T while dumping Bridge Domains", msg)
+		}
+	}
+}
+
+
+// bridgeInfoFromDetails converts a raw l2.BridgeDomainDetails reply into the
+// typed BridgeInfo shared by DumpAllBridges and DumpAllBridgesWithStream.
+func bridgeInfoFromDetails(reply *l2.BridgeDomainDetails) BridgeInfo {
+	info := BridgeInfo{
+		BdID:         reply.BdID,
+		Flood:        reply.Flood,
+		UuFlood:      reply.UuFlood,
+		Forward:      reply.Forward,
+		Learn:        reply.Learn,
+		ArpTerm:      reply.ArpTerm,
+		ArpUfwd:      reply.ArpUfwd,
+		MacAge:       reply.MacAge,
+		BdTag:        string(reply.BdTag),
+		BviSwIfIndex: reply.BviSwIfIndex,
+	}
+
+	for i := uint32(0); i < reply.NSwIfs; i++ {
+		info.Members = append(info.Members, BridgeMember{
+			SwIfIndex: reply.SwIfDetails[i].SwIfIndex,
+			Shg:       reply.SwIfDetails[i].Shg,
+		})
+	}
+
+	return info
+}
+
+
 //
 // Local Functions
 //
@@ -281,3 +741,180 @@ func findBridge(ch *api.Channel, bridgeDomain uint32) (bool,uint32) {
 }
 
 
+// Create a BVI via the native l2.BviCreate message.
+func bviCreate(ch *api.Channel, mac net.HardwareAddr) (uint32, error) {
+
+	req := &l2.BviCreate{
+		Mac: []byte(mac),
+	}
+
+	reply := &l2.BviCreateReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return reply.SwIfIndex, nil
+}
+
+
+// Create a BVI via interfaces.LoopbackCreate, for VPP versions that predate
+// l2.BviCreate.
+func loopbackCreate(ch *api.Channel, mac net.HardwareAddr) (uint32, error) {
+
+	req := &interfaces.LoopbackCreate{
+		MacAddress: []byte(mac),
+	}
+
+	reply := &interfaces.LoopbackCreateReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return reply.SwIfIndex, nil
+}
+
+
+// Delete a BVI created via the native l2.BviCreate message.
+func bviDelete(ch *api.Channel, swIfIndex uint32) error {
+
+	req := &l2.BviDelete{
+		SwIfIndex: swIfIndex,
+	}
+
+	reply := &l2.BviDeleteReply{}
+
+	return ch.SendRequest(req).ReceiveReply(reply)
+}
+
+
+// Delete a BVI created via the interfaces.LoopbackCreate fallback.
+func loopbackDelete(ch *api.Channel, swIfIndex uint32) error {
+
+	req := &interfaces.DeleteLoopback{
+		SwIfIndex: swIfIndex,
+	}
+
+	reply := &interfaces.DeleteLoopbackReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		if debugBridge {
+			fmt.Println("Error deleting BVI loopback:", err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+
+// Turn ArpTerm on for a Bridge Domain if it isn't already, leaving every
+// other attribute untouched. bridge_domain_add_del_v2 updates an existing
+// Bridge Domain's flags in place when IsAdd=1 is sent for an id that
+// already exists, so no delete/re-add cycle is needed.
+func enableArpTerm(ch *api.Channel, bridgeDomain uint32) error {
+
+	req := &l2.BridgeDomainDump{
+		BdID: bridgeDomain,
+	}
+
+	details := &l2.BridgeDomainDetails{}
+
+	if err := ch.SendRequest(req).ReceiveReply(details); err != nil {
+		return err
+	}
+
+	if details.ArpTerm != 0 {
+		return nil
+	}
+
+	_, err := UpdateBridgeOptions(ch, bridgeDomain, BridgeOptions{
+		Flood:   details.Flood,
+		UuFlood: details.UuFlood,
+		Forward: details.Forward,
+		Learn:   details.Learn,
+		ArpTerm: 1,
+		ArpUfwd: details.ArpUfwd,
+		MacAge:  details.MacAge,
+		BdTag:   string(details.BdTag),
+	})
+
+	return err
+}
+
+
+// Add or delete a single ARP-termination entry via l2.BdIPMacAddDel.
+func bdIPMacAddDel(ch *api.Channel, bridgeDomain uint32, ip net.IP, mac net.HardwareAddr, isAdd uint8) error {
+
+	req := &l2.BdIPMacAddDel{
+		BdID:      bridgeDomain,
+		MacAddress: []byte(mac),
+		IsAdd:     isAdd,
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		req.IsIpv6 = 0
+		req.IPAddress = ip4
+	} else {
+		req.IsIpv6 = 1
+		req.IPAddress = ip.To16()
+	}
+
+	reply := &l2.BdIPMacAddDelReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		if debugBridge {
+			fmt.Println("Error adding/deleting ARP termination entry:", err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+
+// Dump the ARP-termination entries of a Bridge Domain via l2.BdIPMacDump.
+func dumpArpTermEntries(ch *api.Channel, bridgeDomain uint32) ([]ArpTermEntry, error) {
+
+	req := &l2.BdIPMacDump{
+		BdID: bridgeDomain,
+	}
+	reqCtx := ch.SendMultiRequest(req)
+
+	var entries []ArpTermEntry
+
+	for {
+		reply := &l2.BdIPMacDetails{}
+		stop, err := reqCtx.ReceiveReply(reply)
+		if stop {
+			break // break out of the loop
+		} else if err != nil {
+			return entries, err
+		}
+
+		var ip net.IP
+		if reply.IsIpv6 != 0 {
+			ip = net.IP(reply.IPAddress)
+		} else {
+			ip = net.IPv4(reply.IPAddress[0], reply.IPAddress[1], reply.IPAddress[2], reply.IPAddress[3])
+		}
+
+		entries = append(entries, ArpTermEntry{
+			IP:  ip,
+			Mac: net.HardwareAddr(reply.MacAddress),
+		})
+	}
+
+	return entries, nil
+}
+
+
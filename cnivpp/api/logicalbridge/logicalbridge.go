@@ -0,0 +1,355 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Binary simple-client is an example VPP management application that exercises the
+// govpp API on real-world use-cases.
+package vpplogicalbridge
+
+// Generates Go bindings for all VPP APIs located in the json directory.
+//go:generate binapi-generator --input-dir=../../bin_api --output-dir=../../bin_api
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"git.fd.io/govpp.git/api"
+	"git.fd.io/govpp.git/core/bin_api/interfaces"
+	"git.fd.io/govpp.git/core/bin_api/l2"
+	"git.fd.io/govpp.git/core/bin_api/vxlan"
+
+	vppbridge "github.com/Billy99/vpp-agent/cnivpp/api/bridge"
+)
+
+
+//
+// Constants
+//
+const debugLogicalBridge = false
+
+
+//
+// Types
+//
+
+// LogicalBridge is the EVPN-style view of a Bridge Domain: a VLAN/VNI pair
+// plus the member interfaces that have been attached to it, instead of the
+// raw BD id that vppbridge deals in.
+type LogicalBridge struct {
+	Name    string
+	BdID    uint32
+	VlanID  uint16
+	Vni     uint32
+	SrcAddress net.IP // local VTEP address; zero value when Vni == 0
+	DstAddress net.IP // remote VTEP address; zero value when Vni == 0
+	VxlanSwIfIndex uint32 // 0 when Vni == 0 (no VXLAN tunnel)
+	Members []uint32      // sw_if_index of each BD member: the VXLAN tunnel (if any) and each attached port/sub-interface
+}
+
+
+//
+// Registry
+//
+
+var (
+	registryLock sync.Mutex
+	registry     = map[string]*LogicalBridge{}
+)
+
+
+//
+// API Functions
+//
+
+// Check whether generated API messages are compatible with the version
+// of VPP which the library is connected to.
+func LogicalBridgeCompatibilityCheck(ch *api.Channel) error {
+	err := ch.CheckMessageCompatibility(
+		&vxlan.VxlanAddDelTunnel{},
+		&vxlan.VxlanAddDelTunnelReply{},
+		&interfaces.CreateSubif{},
+		&interfaces.CreateSubifReply{},
+		&l2.L2InterfaceVlanTagRewrite{},
+		&l2.L2InterfaceVlanTagRewriteReply{},
+	)
+	if err != nil {
+		if debugLogicalBridge {
+			fmt.Println("VPP memif failed compatibility")
+		}
+	}
+
+	return err
+}
+
+
+// Create a LogicalBridge named name, backed by a freshly allocated Bridge
+// Domain. When vni is non-zero, a VXLAN tunnel between srcAddress (the
+// local VTEP) and dstAddress (the remote VTEP) is created and placed in the
+// Bridge Domain so the VLAN is carried over the VXLAN overlay; vlanID is
+// remembered so later AttachPort() calls know which dot1q tag to rewrite
+// on ingress. srcAddress/dstAddress are ignored when vni is 0.
+func CreateLogicalBridge(ch *api.Channel, name string, vlanID uint16, vni uint32, srcAddress net.IP, dstAddress net.IP) (*LogicalBridge, error) {
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, exists := registry[name]; exists {
+		if debugLogicalBridge {
+			fmt.Printf("LogicalBridge %s already exists, exit\n", name)
+		}
+		return registry[name], nil
+	}
+
+	bdID, err := vppbridge.CreateBridgeWithOptions(ch, vppbridge.BdIDAuto, vppbridge.BridgeOptions{
+		Flood:   1,
+		UuFlood: 1,
+		Forward: 1,
+		Learn:   1,
+	})
+	if err != nil {
+		if debugLogicalBridge {
+			fmt.Println("Error allocating Bridge Domain for LogicalBridge:", err)
+		}
+		return nil, err
+	}
+
+	lb := &LogicalBridge{
+		Name:   name,
+		BdID:   bdID,
+		VlanID: vlanID,
+		Vni:    vni,
+	}
+
+	if vni != 0 {
+		swIfIndex, err := createVxlanTunnel(ch, vni, srcAddress, dstAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := vppbridge.AddBridgeInterface(ch, bdID, swIfIndex); err != nil {
+			return nil, err
+		}
+
+		lb.SrcAddress = srcAddress
+		lb.DstAddress = dstAddress
+		lb.VxlanSwIfIndex = swIfIndex
+		lb.Members = append(lb.Members, swIfIndex)
+	}
+
+	registry[name] = lb
+
+	return lb, nil
+}
+
+
+// Delete the LogicalBridge named name, tearing down its VXLAN tunnel (if
+// any) and its Bridge Domain. vppbridge.DeleteBridge leaves a Bridge Domain
+// in place while interfaces are still attached to it, so in that case the
+// Bridge Domain stays while the LogicalBridge is still removed from the
+// registry. Member interfaces are left as-is; callers should
+// detach ports with vppbridge.RemoveBridgeInterface beforehand if they need
+// them freed first (AttachPort only attaches, so it cannot be used here).
+func DeleteLogicalBridge(ch *api.Channel, name string) error {
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	lb, exists := registry[name]
+	if !exists {
+		return nil
+	}
+
+	if lb.Vni != 0 {
+		if err := deleteVxlanTunnel(ch, lb.Vni, lb.SrcAddress, lb.DstAddress); err != nil {
+			return err
+		}
+	}
+
+	if err := vppbridge.DeleteBridge(ch, lb.BdID); err != nil {
+		return err
+	}
+
+	delete(registry, name)
+
+	return nil
+}
+
+
+// Attach swIfIndex to the LogicalBridge named name. When untagged is true,
+// swIfIndex is added to the Bridge Domain directly (e.g. an access port).
+// Otherwise a dot1q sub-interface tagged with the LogicalBridge's VlanID is
+// created on top of swIfIndex via interfaces.CreateSubif, its ingress tag
+// is normalized with l2.L2InterfaceVlanTagRewrite, and the sub-interface
+// (not swIfIndex itself) is added to the Bridge Domain.
+func AttachPort(ch *api.Channel, name string, swIfIndex uint32, untagged bool) error {
+
+	registryLock.Lock()
+	lb, exists := registry[name]
+	registryLock.Unlock()
+
+	if !exists {
+		return fmt.Errorf("LogicalBridge %s does not exist", name)
+	}
+
+	memberSwIfIndex := swIfIndex
+
+	if !untagged {
+		subIfIndex, err := createTaggedSubif(ch, swIfIndex, lb.VlanID)
+		if err != nil {
+			return err
+		}
+		memberSwIfIndex = subIfIndex
+	}
+
+	if err := vppbridge.AddBridgeInterface(ch, lb.BdID, memberSwIfIndex); err != nil {
+		return err
+	}
+
+	registryLock.Lock()
+	lb.Members = append(lb.Members, memberSwIfIndex)
+	registryLock.Unlock()
+
+	return nil
+}
+
+
+// Dump the logical view (name, VLAN, VNI, member interfaces) of the named
+// LogicalBridge to Stdout, rather than the raw BD id that
+// vppbridge.DumpBridge prints.
+func DumpBridge(name string) {
+
+	registryLock.Lock()
+	lb, exists := registry[name]
+	registryLock.Unlock()
+
+	if !exists {
+		fmt.Printf("LogicalBridge %s does NOT Exist.\n", name)
+		return
+	}
+
+	fmt.Printf("    LogicalBridge %s: BdID=%d Vlan=%d Vni=%d VxlanSwId=%d Members=%v\n",
+		lb.Name, lb.BdID, lb.VlanID, lb.Vni, lb.VxlanSwIfIndex, lb.Members)
+}
+
+
+//
+// Local Functions
+//
+
+// Create a VXLAN tunnel between srcAddress and dstAddress for the given VNI
+// and return its sw_if_index. A tunnel is keyed by its VTEP endpoints, so
+// both addresses are required and must be the same IP family.
+func createVxlanTunnel(ch *api.Channel, vni uint32, srcAddress net.IP, dstAddress net.IP) (uint32, error) {
+
+	req := &vxlan.VxlanAddDelTunnel{
+		Vni:   vni,
+		IsAdd: 1,
+	}
+	populateVtepAddresses(req, srcAddress, dstAddress)
+
+	reply := &vxlan.VxlanAddDelTunnelReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		if debugLogicalBridge {
+			fmt.Println("Error creating VXLAN tunnel:", err)
+		}
+		return 0, err
+	}
+
+	return reply.SwIfIndex, nil
+}
+
+
+// Delete the VXLAN tunnel between srcAddress and dstAddress for the given
+// VNI.
+func deleteVxlanTunnel(ch *api.Channel, vni uint32, srcAddress net.IP, dstAddress net.IP) error {
+
+	req := &vxlan.VxlanAddDelTunnel{
+		Vni:   vni,
+		IsAdd: 0,
+	}
+	populateVtepAddresses(req, srcAddress, dstAddress)
+
+	reply := &vxlan.VxlanAddDelTunnelReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		if debugLogicalBridge {
+			fmt.Println("Error deleting VXLAN tunnel:", err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+
+// populateVtepAddresses fills in the source/destination VTEP address and
+// IsIpv6 fields of a VxlanAddDelTunnel request.
+func populateVtepAddresses(req *vxlan.VxlanAddDelTunnel, srcAddress net.IP, dstAddress net.IP) {
+	if src4, dst4 := srcAddress.To4(), dstAddress.To4(); src4 != nil && dst4 != nil {
+		req.IsIpv6 = 0
+		req.SrcAddress = src4
+		req.DstAddress = dst4
+		return
+	}
+
+	req.IsIpv6 = 1
+	req.SrcAddress = srcAddress.To16()
+	req.DstAddress = dstAddress.To16()
+}
+
+
+// Create a dot1q sub-interface of parentSwIfIndex tagged with vlanID, and
+// rewrite its ingress tag so tagged traffic is normalized before it reaches
+// the Bridge Domain.
+func createTaggedSubif(ch *api.Channel, parentSwIfIndex uint32, vlanID uint16) (uint32, error) {
+
+	req := &interfaces.CreateSubif{
+		SwIfIndex: parentSwIfIndex,
+		SubID:     uint32(vlanID),
+		SubIfFlags: 2, // one_tag
+		SubOuterVlanID: vlanID,
+	}
+
+	reply := &interfaces.CreateSubifReply{}
+
+	if err := ch.SendRequest(req).ReceiveReply(reply); err != nil {
+		if debugLogicalBridge {
+			fmt.Println("Error creating sub-interface:", err)
+		}
+		return 0, err
+	}
+
+	rewriteReq := &l2.L2InterfaceVlanTagRewrite{
+		SwIfIndex: reply.SwIfIndex,
+		VtrOp:     3, // pop 1 tag on ingress
+		PushDot1q: 0,
+		Tag1:      uint32(vlanID),
+	}
+
+	rewriteReply := &l2.L2InterfaceVlanTagRewriteReply{}
+
+	if err := ch.SendRequest(rewriteReq).ReceiveReply(rewriteReply); err != nil {
+		if debugLogicalBridge {
+			fmt.Println("Error rewriting VLAN tag:", err)
+		}
+		return 0, err
+	}
+
+	return reply.SwIfIndex, nil
+}
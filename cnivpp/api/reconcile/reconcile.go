@@ -0,0 +1,435 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Binary simple-client is an example VPP management application that exercises the
+// govpp API on real-world use-cases.
+package vppreconcile
+
+// Generates Go bindings for all VPP APIs located in the json directory.
+//go:generate binapi-generator --input-dir=../../bin_api --output-dir=../../bin_api
+
+import (
+	"context"
+	"fmt"
+
+	"git.fd.io/govpp.git/api"
+	"git.fd.io/govpp.git/core/bin_api/interfaces"
+	"git.fd.io/govpp.git/core/bin_api/l2"
+
+	vppbridge "github.com/Billy99/vpp-agent/cnivpp/api/bridge"
+	vppxconnect "github.com/Billy99/vpp-agent/cnivpp/api/xconnect"
+)
+
+
+//
+// Constants
+//
+const debugReconcile = false
+
+
+//
+// Types
+//
+
+// InterfaceSpec is the desired admin state of one interface.
+type InterfaceSpec struct {
+	SwIfIndex uint32
+	AdminUp   bool
+}
+
+// BridgeSpec is a Bridge Domain that is expected to exist, with the given
+// options.
+type BridgeSpec struct {
+	BdID    uint32
+	Options vppbridge.BridgeOptions
+}
+
+// BridgeMemberSpec is an interface that is expected to be a member of a
+// Bridge Domain.
+type BridgeMemberSpec struct {
+	BdID      uint32
+	SwIfIndex uint32
+}
+
+// XConnectSpec is a unidirectional cross-connect that is expected to exist.
+type XConnectSpec struct {
+	RxSwIfIndex uint32
+	TxSwIfIndex uint32
+}
+
+// Desired is the full target state a Reconcile() call converges VPP to.
+type Desired struct {
+	Interfaces []InterfaceSpec
+	Bridges    []BridgeSpec
+	Members    []BridgeMemberSpec
+	XConnects  []XConnectSpec
+}
+
+// OpKind identifies which VPP message a PlannedOp issues.
+type OpKind string
+
+const (
+	OpSetInterfaceFlags OpKind = "SwInterfaceSetFlags"
+	OpCreateBridge      OpKind = "BridgeDomainAddDel"
+	OpUpdateBridge      OpKind = "BridgeDomainAddDelUpdate"
+	OpDeleteBridge      OpKind = "BridgeDomainAddDelDelete"
+	OpSetL2Bridge       OpKind = "SwInterfaceSetL2Bridge"
+	OpDeleteL2Bridge    OpKind = "SwInterfaceSetL2BridgeDelete"
+	OpSetL2XConnect     OpKind = "SwInterfaceSetL2Xconnect"
+	OpDeleteL2XConnect  OpKind = "SwInterfaceSetL2XconnectDelete"
+)
+
+// PlannedOp is one VPP operation needed to converge current state to
+// Desired. Plan() returns these without applying them; Reconcile() applies
+// them over a Stream.
+type PlannedOp struct {
+	Kind      OpKind
+	SwIfIndex uint32
+	AdminUp   bool
+	Bridge    BridgeSpec
+	Member    BridgeMemberSpec
+	XConnect  XConnectSpec
+}
+
+func (op PlannedOp) String() string {
+	switch op.Kind {
+	case OpSetInterfaceFlags:
+		return fmt.Sprintf("%s: SwIfIndex=%d AdminUp=%v", op.Kind, op.SwIfIndex, op.AdminUp)
+	case OpCreateBridge, OpUpdateBridge, OpDeleteBridge:
+		return fmt.Sprintf("%s: BdID=%d", op.Kind, op.Bridge.BdID)
+	case OpSetL2Bridge, OpDeleteL2Bridge:
+		return fmt.Sprintf("%s: BdID=%d SwIfIndex=%d", op.Kind, op.Member.BdID, op.Member.SwIfIndex)
+	case OpSetL2XConnect, OpDeleteL2XConnect:
+		return fmt.Sprintf("%s: RxSwIfIndex=%d TxSwIfIndex=%d", op.Kind, op.XConnect.RxSwIfIndex, op.XConnect.TxSwIfIndex)
+	default:
+		return string(op.Kind)
+	}
+}
+
+func (op PlannedOp) apply(s api.Stream) error {
+	switch op.Kind {
+	case OpSetInterfaceFlags:
+		up := uint8(0)
+		if op.AdminUp {
+			up = 1
+		}
+		req := &interfaces.SwInterfaceSetFlags{
+			SwIfIndex:   op.SwIfIndex,
+			AdminUpDown: up,
+		}
+		if err := s.SendMsg(req); err != nil {
+			return err
+		}
+		_, err := s.RecvMsg()
+		return err
+
+	case OpCreateBridge, OpUpdateBridge:
+		// bridge_domain_add_del_v2 updates an existing Bridge Domain's
+		// attributes in place when IsAdd=1 is sent for an id that already
+		// exists, so create and update share the same call.
+		_, err := vppbridge.CreateBridgeWithStream(s, op.Bridge.BdID, op.Bridge.Options)
+		return err
+
+	case OpDeleteBridge:
+		req := &l2.BridgeDomainAddDel{
+			BdID:  op.Bridge.BdID,
+			IsAdd: 0,
+		}
+		if err := s.SendMsg(req); err != nil {
+			return err
+		}
+		_, err := s.RecvMsg()
+		return err
+
+	case OpSetL2Bridge:
+		return vppbridge.AddBridgeInterfaceWithStream(s, op.Member.BdID, op.Member.SwIfIndex)
+
+	case OpDeleteL2Bridge:
+		req := &l2.SwInterfaceSetL2Bridge{
+			BdID:        op.Member.BdID,
+			RxSwIfIndex: op.Member.SwIfIndex,
+			Enable:      0,
+		}
+		if err := s.SendMsg(req); err != nil {
+			return err
+		}
+		_, err := s.RecvMsg()
+		return err
+
+	case OpSetL2XConnect, OpDeleteL2XConnect:
+		enable := uint8(1)
+		if op.Kind == OpDeleteL2XConnect {
+			enable = 0
+		}
+		req := &l2.SwInterfaceSetL2Xconnect{
+			RxSwIfIndex: op.XConnect.RxSwIfIndex,
+			TxSwIfIndex: op.XConnect.TxSwIfIndex,
+			Enable:      enable,
+		}
+		if err := s.SendMsg(req); err != nil {
+			return err
+		}
+		_, err := s.RecvMsg()
+		return err
+
+	default:
+		return fmt.Errorf("unknown op kind %q", op.Kind)
+	}
+}
+
+
+//
+// API Functions
+//
+
+// Plan dumps current BDs/interfaces once, diffs against desired, and
+// returns the minimum set of operations needed to converge VPP to it -
+// including tearing down or updating live state that has drifted away from
+// Desired, not just creating what's missing - without applying any of them.
+func Plan(ch *api.Channel, desired Desired) ([]PlannedOp, error) {
+
+	currentBridges, err := vppbridge.DumpAllBridges(ch)
+	if err != nil {
+		return nil, err
+	}
+	bridgeByID := make(map[uint32]vppbridge.BridgeInfo, len(currentBridges))
+	for _, bd := range currentBridges {
+		bridgeByID[bd.BdID] = bd
+	}
+
+	currentXConnects, err := vppxconnect.DumpXConnects(ch)
+	if err != nil {
+		return nil, err
+	}
+	xconnectExists := make(map[[2]uint32]bool, len(currentXConnects))
+	for _, xc := range currentXConnects {
+		xconnectExists[[2]uint32{xc.RxSwIfIndex, xc.TxSwIfIndex}] = true
+	}
+
+	currentIfaces, err := dumpInterfaceFlags(ch)
+	if err != nil {
+		return nil, err
+	}
+
+	wantBridgeByID := make(map[uint32]BridgeSpec, len(desired.Bridges))
+	for _, want := range desired.Bridges {
+		wantBridgeByID[want.BdID] = want
+	}
+
+	wantMember := make(map[[2]uint32]bool, len(desired.Members))
+	for _, want := range desired.Members {
+		wantMember[[2]uint32{want.BdID, want.SwIfIndex}] = true
+	}
+
+	wantXConnect := make(map[[2]uint32]bool, len(desired.XConnects))
+	for _, want := range desired.XConnects {
+		wantXConnect[[2]uint32{want.RxSwIfIndex, want.TxSwIfIndex}] = true
+	}
+
+	var ops []PlannedOp
+
+	// Tear down members, bridges and xconnects that exist but are no longer
+	// (or never were) desired, members first so a now-undesired Bridge
+	// Domain has no members left by the time its own delete op runs.
+	for _, bd := range currentBridges {
+		for _, member := range bd.Members {
+			if !wantMember[[2]uint32{bd.BdID, member.SwIfIndex}] {
+				ops = append(ops, PlannedOp{Kind: OpDeleteL2Bridge, Member: BridgeMemberSpec{BdID: bd.BdID, SwIfIndex: member.SwIfIndex}})
+			}
+		}
+
+		want, exists := wantBridgeByID[bd.BdID]
+		if !exists {
+			ops = append(ops, PlannedOp{Kind: OpDeleteBridge, Bridge: BridgeSpec{BdID: bd.BdID}})
+		} else if bridgeOptionsDiffer(want.Options, bd) {
+			ops = append(ops, PlannedOp{Kind: OpUpdateBridge, Bridge: want})
+		}
+	}
+
+	for _, xc := range currentXConnects {
+		if !wantXConnect[[2]uint32{xc.RxSwIfIndex, xc.TxSwIfIndex}] {
+			ops = append(ops, PlannedOp{Kind: OpDeleteL2XConnect, XConnect: XConnectSpec{RxSwIfIndex: xc.RxSwIfIndex, TxSwIfIndex: xc.TxSwIfIndex}})
+		}
+	}
+
+	// Then bring up anything desired that doesn't exist yet.
+	for _, want := range desired.Bridges {
+		if _, exists := bridgeByID[want.BdID]; !exists {
+			ops = append(ops, PlannedOp{Kind: OpCreateBridge, Bridge: want})
+		}
+	}
+
+	for _, want := range desired.Members {
+		bd, exists := bridgeByID[want.BdID]
+		if !exists || !isBridgeMember(bd, want.SwIfIndex) {
+			ops = append(ops, PlannedOp{Kind: OpSetL2Bridge, Member: want})
+		}
+	}
+
+	for _, want := range desired.XConnects {
+		if !xconnectExists[[2]uint32{want.RxSwIfIndex, want.TxSwIfIndex}] {
+			ops = append(ops, PlannedOp{Kind: OpSetL2XConnect, XConnect: want})
+		}
+	}
+
+	for _, want := range desired.Interfaces {
+		if currentIfaces[want.SwIfIndex] != want.AdminUp {
+			ops = append(ops, PlannedOp{Kind: OpSetInterfaceFlags, SwIfIndex: want.SwIfIndex, AdminUp: want.AdminUp})
+		}
+	}
+
+	return ops, nil
+}
+
+
+// Reconcile converges VPP to desired in a single call: it plans the
+// required operations, then batches them over a govpp Stream
+// (conn.NewStream(ctx)) - see the "Stream API" section of
+// cnivpp/api/bridge/bridge.go for why a Stream instead of per-op
+// Channel.SendRequest calls. It returns the operations that were applied.
+func Reconcile(ctx context.Context, conn api.Connection, ch *api.Channel, desired Desired) ([]PlannedOp, error) {
+
+	ops, err := Plan(ch, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ops) == 0 {
+		return ops, nil
+	}
+
+	stream, err := conn.NewStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	for i, op := range ops {
+		if err := op.apply(stream); err != nil {
+			if debugReconcile {
+				fmt.Println("Error applying op", op, ":", err)
+			}
+			return ops[:i], err
+		}
+	}
+
+	return ops, nil
+}
+
+
+// Watch subscribes to SwInterfaceEvent notifications and calls Reconcile
+// against desired every time one arrives, so admin-state drift on a
+// watched interface re-triggers reconciliation. It returns a channel of the
+// operations applied on each reconciliation; a reconciliation that fails or
+// applies no operation sends nothing. The channel is closed when ctx is
+// cancelled.
+func Watch(ctx context.Context, conn api.Connection, ch *api.Channel, desired Desired) (<-chan []PlannedOp, error) {
+
+	notifCh := make(chan api.Message, 100)
+
+	sub, err := ch.SubscribeNotification(notifCh, &interfaces.SwInterfaceEvent{})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan []PlannedOp)
+
+	go func() {
+		defer close(results)
+		defer ch.UnsubscribeNotification(sub)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-notifCh:
+				ops, err := Reconcile(ctx, conn, ch, desired)
+				if err != nil {
+					if debugReconcile {
+						fmt.Println("Error reconciling after SwInterfaceEvent:", err)
+					}
+					continue
+				}
+				if len(ops) == 0 {
+					continue
+				}
+				// results has no reader guarantee, so this send must not
+				// block shutdown: without selecting on ctx.Done() here too,
+				// a caller that stops draining results wedges this
+				// goroutine forever, leaking the VPP subscription.
+				select {
+				case results <- ops:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return results, nil
+}
+
+
+//
+// Local Functions
+//
+
+// isBridgeMember reports whether swIfIndex is already a member of bd.
+func isBridgeMember(bd vppbridge.BridgeInfo, swIfIndex uint32) bool {
+	for _, member := range bd.Members {
+		if member.SwIfIndex == swIfIndex {
+			return true
+		}
+	}
+	return false
+}
+
+
+// bridgeOptionsDiffer reports whether a live Bridge Domain's attributes
+// have drifted from the wanted BridgeOptions.
+func bridgeOptionsDiffer(want vppbridge.BridgeOptions, have vppbridge.BridgeInfo) bool {
+	return want.Flood != have.Flood ||
+		want.UuFlood != have.UuFlood ||
+		want.Forward != have.Forward ||
+		want.Learn != have.Learn ||
+		want.ArpTerm != have.ArpTerm ||
+		want.ArpUfwd != have.ArpUfwd ||
+		want.MacAge != have.MacAge ||
+		want.BdTag != have.BdTag
+}
+
+
+// dumpInterfaceFlags dumps the admin state of every interface, keyed by
+// sw_if_index.
+func dumpInterfaceFlags(ch *api.Channel) (map[uint32]bool, error) {
+
+	req := &interfaces.SwInterfaceDump{}
+	reqCtx := ch.SendMultiRequest(req)
+
+	result := make(map[uint32]bool)
+
+	for {
+		reply := &interfaces.SwInterfaceDetails{}
+		stop, err := reqCtx.ReceiveReply(reply)
+		if stop {
+			break // break out of the loop
+		} else if err != nil {
+			return result, err
+		}
+
+		result[reply.SwIfIndex] = reply.AdminUpDown != 0
+	}
+
+	return result, nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Binary simple-client is an example VPP management application that exercises the
+// govpp API on real-world use-cases.
+package vppxconnect
+
+// Generates Go bindings for all VPP APIs located in the json directory.
+//go:generate binapi-generator --input-dir=../../bin_api --output-dir=../../bin_api
+
+import (
+	"fmt"
+
+	"git.fd.io/govpp.git/api"
+	"git.fd.io/govpp.git/core/bin_api/l2"
+)
+
+
+//
+// Constants
+//
+const debugXConnect = false
+
+
+//
+// API Functions
+//
+
+
+// Check whether generated API messages are compatible with the version
+// of VPP which the library is connected to.
+func XConnectCompatibilityCheck(ch *api.Channel) error {
+	err := ch.CheckMessageCompatibility(
+		&l2.SwInterfaceSetL2Xconnect{},
+		&l2.SwInterfaceSetL2XconnectReply{},
+		&l2.L2XconnectDump{},
+		&l2.L2XconnectDetails{},
+	)
+	if err != nil {
+		if debugXConnect {
+			fmt.Println("VPP memif failed compatibility")
+		}
+	}
+
+	return err
+}
+
+
+// Attempt to cross-connect two interfaces, receive side to transmit side.
+// Unlike a Bridge Domain, an xconnect is unidirectional, so the reverse
+// direction (txSwIfIndex -> rxSwIfIndex) must be created separately if
+// bidirectional traffic is desired.
+func CreateXConnect(ch *api.Channel, rxSwIfIndex uint32, txSwIfIndex uint32) error {
+
+	// Populate the Request Structure
+	req := &l2.SwInterfaceSetL2Xconnect{
+		RxSwIfIndex: rxSwIfIndex,
+		TxSwIfIndex: txSwIfIndex,
+		Enable: 1,
+	}
+
+	reply := &l2.SwInterfaceSetL2XconnectReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		if debugXConnect {
+			fmt.Println("Error creating xconnect:", err)
+		}
+		return err
+	}
+
+	return err
+}
+
+
+// Attempt to remove the cross-connect on the receive side interface.
+func DeleteXConnect(ch *api.Channel, rxSwIfIndex uint32, txSwIfIndex uint32) error {
+
+	// Populate the Request Structure
+	req := &l2.SwInterfaceSetL2Xconnect{
+		RxSwIfIndex: rxSwIfIndex,
+		TxSwIfIndex: txSwIfIndex,
+		Enable: 0,
+	}
+
+	reply := &l2.SwInterfaceSetL2XconnectReply{}
+
+	err := ch.SendRequest(req).ReceiveReply(reply)
+
+	if err != nil {
+		if debugXConnect {
+			fmt.Println("Error deleting xconnect:", err)
+		}
+		return err
+	}
+
+	return err
+}
+
+
+// XConnectInfo describes one direction of an existing cross-connect.
+type XConnectInfo struct {
+	RxSwIfIndex uint32
+	TxSwIfIndex uint32
+}
+
+
+// Dump all the configured xconnects. There is no way to query a single
+// xconnect by interface, so the full list is always returned.
+func DumpXConnects(ch *api.Channel) ([]XConnectInfo, error) {
+	var list []XConnectInfo
+
+	req := &l2.L2XconnectDump{}
+	reqCtx := ch.SendMultiRequest(req)
+
+	for {
+		reply := &l2.L2XconnectDetails{}
+		stop, err := reqCtx.ReceiveReply(reply)
+		if stop {
+			break // break out of the loop
+		} else if err != nil {
+			if debugXConnect {
+				fmt.Println("Error dumping xconnects:", err)
+			}
+			return list, err
+		}
+
+		list = append(list, XConnectInfo{
+			RxSwIfIndex: reply.RxSwIfIndex,
+			TxSwIfIndex: reply.TxSwIfIndex,
+		})
+	}
+
+	return list, nil
+}